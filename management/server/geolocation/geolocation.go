@@ -0,0 +1,19 @@
+// Package geolocation provides peer.GeoResolver implementations that resolve a connection IP to
+// country/city/ASN information, either from a local MaxMind MMDB database or from an HTTP geo-IP service.
+package geolocation
+
+import (
+	"errors"
+	"net"
+)
+
+// errIPNotFound is returned by resolvers when no record could be found for a given IP.
+var errIPNotFound = errors.New("no geo location record found for ip")
+
+// validateIP returns an error if ip is nil.
+func validateIP(ip net.IP) error {
+	if ip == nil {
+		return errors.New("ip address is nil")
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package geolocation
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/netbirdio/netbird/management/server/peer"
+)
+
+// defaultHTTPTimeout bounds a single lookup request to the remote geo-IP service.
+const defaultHTTPTimeout = 5 * time.Second
+
+// httpLocationResponse is the shape expected from the remote geo-IP service's response body.
+type httpLocationResponse struct {
+	CountryCode string `json:"country_code"`
+	CityName    string `json:"city_name"`
+	GeoNameID   uint   `json:"geoname_id"`
+	Continent   string `json:"continent_code"`
+	ASN         uint   `json:"asn"`
+	ASOrg       string `json:"as_org"`
+}
+
+// HTTPResolver resolves locations via an HTTP geo-IP service, for deployments (e.g. managed cloud)
+// where shipping and hot-reloading a local MMDB file isn't practical.
+type HTTPResolver struct {
+	baseURL string
+	client  *http.Client
+}
+
+// NewHTTPResolver creates an HTTPResolver that queries baseURL, expected to accept a GET request with an
+// "ip" query parameter and return a JSON body matching httpLocationResponse.
+func NewHTTPResolver(baseURL string, client *http.Client) *HTTPResolver {
+	if client == nil {
+		client = &http.Client{Timeout: defaultHTTPTimeout}
+	}
+	return &HTTPResolver{baseURL: baseURL, client: client}
+}
+
+// Resolve implements peer.GeoResolver.
+func (r *HTTPResolver) Resolve(ip net.IP) (peer.Location, error) {
+	if err := validateIP(ip); err != nil {
+		return peer.Location{}, err
+	}
+
+	u, err := url.Parse(r.baseURL)
+	if err != nil {
+		return peer.Location{}, fmt.Errorf("parse geo-ip service url: %w", err)
+	}
+	q := u.Query()
+	q.Set("ip", ip.String())
+	u.RawQuery = q.Encode()
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultHTTPTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return peer.Location{}, fmt.Errorf("build geo-ip request: %w", err)
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return peer.Location{}, fmt.Errorf("query geo-ip service: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return peer.Location{}, errIPNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return peer.Location{}, fmt.Errorf("geo-ip service returned status %d", resp.StatusCode)
+	}
+
+	var body httpLocationResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return peer.Location{}, fmt.Errorf("decode geo-ip response: %w", err)
+	}
+
+	return peer.Location{
+		CountryCode: body.CountryCode,
+		CityName:    body.CityName,
+		GeoNameID:   body.GeoNameID,
+		Continent:   body.Continent,
+		ASN:         body.ASN,
+		ASOrg:       body.ASOrg,
+	}, nil
+}
@@ -0,0 +1,53 @@
+package geolocation
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+)
+
+func TestHTTPResolver_Resolve(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("ip") != "203.0.113.10" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(httpLocationResponse{
+			CountryCode: "DE",
+			CityName:    "Berlin",
+			GeoNameID:   2950159,
+			Continent:   "EU",
+			ASN:         64500,
+			ASOrg:       "Example Org",
+		})
+	}))
+	defer srv.Close()
+
+	resolver := NewHTTPResolver(srv.URL, nil)
+
+	ip := netip.MustParseAddr("203.0.113.10").AsSlice()
+	loc, err := resolver.Resolve(ip)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if loc.CountryCode != "DE" || loc.ASN != 64500 || loc.Continent != "EU" {
+		t.Fatalf("unexpected location: %+v", loc)
+	}
+}
+
+func TestHTTPResolver_Resolve_NotFound(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	resolver := NewHTTPResolver(srv.URL, nil)
+
+	ip := netip.MustParseAddr("203.0.113.10").AsSlice()
+	if _, err := resolver.Resolve(ip); err != errIPNotFound {
+		t.Fatalf("expected errIPNotFound, got %v", err)
+	}
+}
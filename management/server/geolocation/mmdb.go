@@ -0,0 +1,199 @@
+package geolocation
+
+import (
+	"fmt"
+	"net"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+
+	"github.com/fsnotify/fsnotify"
+	geoip2 "github.com/oschwald/geoip2-golang"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/netbirdio/netbird/management/server/peer"
+)
+
+// MMDBResolver resolves locations from local MaxMind city and ASN databases. It watches the directories
+// containing both files and hot-reloads the readers on change, so the management service doesn't need to
+// be restarted to pick up a refreshed database.
+type MMDBResolver struct {
+	cityPath string
+	asnPath  string
+
+	mu         sync.RWMutex
+	cityReader *geoip2.Reader
+	asnReader  *geoip2.Reader
+
+	watcher   *fsnotify.Watcher
+	closeOnce sync.Once
+	done      chan struct{}
+
+	// reloaded counts successful hot reloads, primarily for tests.
+	reloaded atomic.Uint64
+
+	// onReload, if set, is invoked after each successful reload (e.g. to trigger re-resolution of peers).
+	onReload func()
+}
+
+// NewMMDBResolver opens the city and ASN databases at the given paths and starts watching their
+// containing directories for changes.
+func NewMMDBResolver(cityPath, asnPath string, onReload func()) (*MMDBResolver, error) {
+	cityReader, err := geoip2.Open(cityPath)
+	if err != nil {
+		return nil, fmt.Errorf("open city mmdb %s: %w", cityPath, err)
+	}
+
+	asnReader, err := geoip2.Open(asnPath)
+	if err != nil {
+		_ = cityReader.Close()
+		return nil, fmt.Errorf("open asn mmdb %s: %w", asnPath, err)
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		_ = cityReader.Close()
+		_ = asnReader.Close()
+		return nil, fmt.Errorf("create mmdb watcher: %w", err)
+	}
+
+	// Watch the containing directories rather than the files themselves: the idiomatic way to update an
+	// mmdb file is to download to a temp path and rename(2) it over the target, which emits a
+	// Remove/Rename on the file's own inode watch and leaves it stale with no further events ever
+	// arriving. A directory's inode survives that rename, so watching it and filtering events by the
+	// configured file paths below keeps hot-reload working across updates.
+	dirs := map[string]struct{}{filepath.Dir(cityPath): {}, filepath.Dir(asnPath): {}}
+	for dir := range dirs {
+		if err := watcher.Add(dir); err != nil {
+			log.Warnf("geolocation: failed to watch directory %s for changes: %v", dir, err)
+		}
+	}
+
+	r := &MMDBResolver{
+		cityPath:   cityPath,
+		asnPath:    asnPath,
+		cityReader: cityReader,
+		asnReader:  asnReader,
+		watcher:    watcher,
+		done:       make(chan struct{}),
+		onReload:   onReload,
+	}
+
+	go r.watch()
+
+	return r, nil
+}
+
+// Resolve implements peer.GeoResolver.
+func (r *MMDBResolver) Resolve(ip net.IP) (peer.Location, error) {
+	if err := validateIP(ip); err != nil {
+		return peer.Location{}, err
+	}
+
+	// The RLock is held for the entire lookup, not just to snapshot the reader pointers: reload() takes
+	// the write lock and closes the old readers (unmapping their mmdb mmap) right after swapping them in,
+	// so releasing the RLock before calling City/ASN would let a reload race a lookup into using a
+	// reader whose backing mmap was just unmapped out from under it.
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	city, err := r.cityReader.City(ip)
+	if err != nil {
+		return peer.Location{}, fmt.Errorf("lookup city for ip %s: %w", ip, err)
+	}
+	if city.City.GeoNameID == 0 && city.Country.IsoCode == "" {
+		return peer.Location{}, errIPNotFound
+	}
+
+	loc := peer.Location{
+		CountryCode: city.Country.IsoCode,
+		CityName:    city.City.Names["en"],
+		GeoNameID:   city.City.GeoNameID,
+		Continent:   city.Continent.Code,
+	}
+
+	// asn may be nil even with err == nil if the database opened at asnPath isn't an ASN database
+	// (geoip2.Open doesn't validate database type), so guard on asn != nil, not just err.
+	asn, err := r.asnReader.ASN(ip)
+	if err != nil || asn == nil {
+		log.Debugf("geolocation: asn lookup failed for ip %s: %v", ip, err)
+		return loc, nil
+	}
+
+	loc.ASN = uint(asn.AutonomousSystemNumber)
+	loc.ASOrg = asn.AutonomousSystemOrganization
+	return loc, nil
+}
+
+// watch reloads the city and ASN readers whenever their underlying files change on disk.
+func (r *MMDBResolver) watch() {
+	for {
+		select {
+		case <-r.done:
+			return
+		case event, ok := <-r.watcher.Events:
+			if !ok {
+				return
+			}
+			if event.Op&(fsnotify.Write|fsnotify.Create) == 0 {
+				continue
+			}
+			if event.Name != r.cityPath && event.Name != r.asnPath {
+				continue
+			}
+			r.reload(event.Name)
+		case err, ok := <-r.watcher.Errors:
+			if !ok {
+				return
+			}
+			log.Warnf("geolocation: mmdb watcher error: %v", err)
+		}
+	}
+}
+
+func (r *MMDBResolver) reload(path string) {
+	reader, err := geoip2.Open(path)
+	if err != nil {
+		log.Warnf("geolocation: failed to reload mmdb %s: %v", path, err)
+		return
+	}
+
+	r.mu.Lock()
+	switch path {
+	case r.cityPath:
+		old := r.cityReader
+		r.cityReader = reader
+		_ = old.Close()
+	case r.asnPath:
+		old := r.asnReader
+		r.asnReader = reader
+		_ = old.Close()
+	default:
+		r.mu.Unlock()
+		_ = reader.Close()
+		return
+	}
+	r.mu.Unlock()
+
+	r.reloaded.Add(1)
+	log.Infof("geolocation: reloaded mmdb %s", path)
+
+	if r.onReload != nil {
+		r.onReload()
+	}
+}
+
+// Close stops the file watcher and closes both database readers.
+func (r *MMDBResolver) Close() error {
+	r.closeOnce.Do(func() {
+		close(r.done)
+		_ = r.watcher.Close()
+	})
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.cityReader.Close(); err != nil {
+		return err
+	}
+	return r.asnReader.Close()
+}
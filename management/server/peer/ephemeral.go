@@ -0,0 +1,196 @@
+package peer
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	log "github.com/sirupsen/logrus"
+)
+
+// DefaultEphemeralTTL is how long an ephemeral peer is kept around after going offline before it is
+// garbage collected, unless overridden per-account or per-setup-key. A TTL of 0 means "delete immediately
+// on disconnect".
+const DefaultEphemeralTTL = 10 * time.Minute
+
+// PendingDeletion is a scheduled ephemeral peer removal, persisted so a management server restart doesn't
+// leak peers whose grace period elapses while the process is down.
+type PendingDeletion struct {
+	PeerID    string
+	AccountID string
+	DeleteAt  time.Time
+}
+
+// PendingDeletionStore persists PendingDeletion records across restarts.
+type PendingDeletionStore interface {
+	SavePendingDeletion(deletion PendingDeletion) error
+	DeletePendingDeletion(peerID string) error
+	ListPendingDeletions() ([]PendingDeletion, error)
+}
+
+// DeleteEphemeralPeerFunc removes peerID from accountID's network: releases its IP, removes it from
+// routes/ACLs/DNS, and emits an activity event.
+type DeleteEphemeralPeerFunc func(accountID, peerID string) error
+
+// EphemeralManager schedules deletion of ephemeral peers once they go offline, cancels the deletion if the
+// peer reconnects before the grace period elapses, and reaps it otherwise.
+type EphemeralManager struct {
+	store    PendingDeletionStore
+	deleteFn DeleteEphemeralPeerFunc
+
+	mu     sync.Mutex
+	timers map[string]*time.Timer
+	// generation tracks, per peer, which armed timer is the current one. fire() only acts if its
+	// generation still matches, so a timer that already fired can't race a concurrent CancelDeletion/arm
+	// (e.g. a rapid reconnect) into reaping a peer that was in fact cancelled, or double-accounting metrics.
+	// Values come from nextGeneration, a manager-wide monotonic counter that is never reset, so a
+	// generation assigned to one armed timer can never be reassigned to a later one: deleting a peer's
+	// entry from this map (on cancel or fire) must never make a stale, still in-flight fire() match a
+	// freshly (re)armed timer for the same peer.
+	generation map[string]uint64
+	// nextGeneration is the source of generation values; see generation's doc comment.
+	nextGeneration uint64
+
+	pendingGauge prometheus.Gauge
+	reapedTotal  prometheus.Counter
+}
+
+// NewEphemeralManager creates an EphemeralManager. registerer may be nil, in which case metrics aren't
+// registered (e.g. in tests).
+func NewEphemeralManager(store PendingDeletionStore, deleteFn DeleteEphemeralPeerFunc, registerer prometheus.Registerer) *EphemeralManager {
+	factory := promauto.With(registerer)
+
+	return &EphemeralManager{
+		store:      store,
+		deleteFn:   deleteFn,
+		timers:     make(map[string]*time.Timer),
+		generation: make(map[string]uint64),
+		pendingGauge: factory.NewGauge(prometheus.GaugeOpts{
+			Namespace: "netbird",
+			Subsystem: "management",
+			Name:      "ephemeral_pending",
+			Help:      "Number of ephemeral peers currently scheduled for deletion.",
+		}),
+		reapedTotal: factory.NewCounter(prometheus.CounterOpts{
+			Namespace: "netbird",
+			Subsystem: "management",
+			Name:      "ephemeral_reaped_total",
+			Help:      "Total number of ephemeral peers removed after their offline grace period elapsed.",
+		}),
+	}
+}
+
+// LoadAndArm reloads pending deletions from the store and re-arms their timers. It should be called once
+// on management server startup. Deletions whose DeleteAt has already passed fire immediately.
+func (m *EphemeralManager) LoadAndArm() error {
+	pending, err := m.store.ListPendingDeletions()
+	if err != nil {
+		return fmt.Errorf("list pending ephemeral deletions: %w", err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, d := range pending {
+		m.arm(d)
+	}
+	return nil
+}
+
+// ScheduleDeletion arms (or re-arms) a deletion timer for peerID, firing after ttl. A ttl of 0 fires the
+// deletion on the next timer tick, i.e. effectively immediately.
+func (m *EphemeralManager) ScheduleDeletion(accountID, peerID string, ttl time.Duration) error {
+	if ttl < 0 {
+		ttl = 0
+	}
+	deletion := PendingDeletion{PeerID: peerID, AccountID: accountID, DeleteAt: time.Now().Add(ttl)}
+
+	if err := m.store.SavePendingDeletion(deletion); err != nil {
+		return fmt.Errorf("persist pending ephemeral deletion for peer %s: %w", peerID, err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.arm(deletion)
+	return nil
+}
+
+// CancelDeletion cancels a pending deletion for peerID, e.g. because the peer reconnected before its grace
+// period elapsed. It is a no-op if no deletion was scheduled.
+func (m *EphemeralManager) CancelDeletion(peerID string) {
+	m.mu.Lock()
+	timer, ok := m.timers[peerID]
+	if !ok {
+		m.mu.Unlock()
+		return
+	}
+	timer.Stop()
+	delete(m.timers, peerID)
+	delete(m.generation, peerID)
+	m.pendingGauge.Dec()
+	m.mu.Unlock()
+
+	if err := m.store.DeletePendingDeletion(peerID); err != nil {
+		log.Warnf("ephemeral: failed to clear persisted pending deletion for peer %s: %v", peerID, err)
+	}
+}
+
+// OnPeerStatusChange schedules or cancels a deletion based on a peer's Connected transition. Pass the
+// effective TTL resolved by the caller (setup key override, account default, or DefaultEphemeralTTL).
+func (m *EphemeralManager) OnPeerStatusChange(accountID, peerID string, connected bool, ttl time.Duration) error {
+	if connected {
+		m.CancelDeletion(peerID)
+		return nil
+	}
+	return m.ScheduleDeletion(accountID, peerID, ttl)
+}
+
+// arm starts (or restarts) the in-memory timer for a persisted deletion. Callers must hold m.mu.
+func (m *EphemeralManager) arm(d PendingDeletion) {
+	if existing, ok := m.timers[d.PeerID]; ok {
+		existing.Stop()
+	} else {
+		m.pendingGauge.Inc()
+	}
+
+	m.nextGeneration++
+	gen := m.nextGeneration
+	m.generation[d.PeerID] = gen
+
+	delay := time.Until(d.DeleteAt)
+	if delay < 0 {
+		delay = 0
+	}
+
+	m.timers[d.PeerID] = time.AfterFunc(delay, func() {
+		m.fire(d.AccountID, d.PeerID, gen)
+	})
+}
+
+// fire performs the actual deletion once a peer's grace period has elapsed. gen is the generation that was
+// current when this timer was armed; if it no longer matches m.generation[peerID] the deletion was either
+// cancelled (e.g. the peer reconnected) or superseded by a newer schedule, and fire is a no-op. This closes
+// the race where timer.Stop() returns false because the timer already fired concurrently with a cancel.
+func (m *EphemeralManager) fire(accountID, peerID string, gen uint64) {
+	m.mu.Lock()
+	if current, ok := m.generation[peerID]; !ok || current != gen {
+		m.mu.Unlock()
+		return
+	}
+	delete(m.timers, peerID)
+	delete(m.generation, peerID)
+	m.pendingGauge.Dec()
+	m.mu.Unlock()
+
+	if err := m.deleteFn(accountID, peerID); err != nil {
+		log.Errorf("ephemeral: failed to reap peer %s: %v", peerID, err)
+		return
+	}
+
+	if err := m.store.DeletePendingDeletion(peerID); err != nil {
+		log.Warnf("ephemeral: failed to clear persisted pending deletion for peer %s: %v", peerID, err)
+	}
+
+	m.reapedTotal.Inc()
+}
@@ -0,0 +1,224 @@
+package peer
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+type memPendingDeletionStore struct {
+	mu   sync.Mutex
+	data map[string]PendingDeletion
+}
+
+func newMemPendingDeletionStore() *memPendingDeletionStore {
+	return &memPendingDeletionStore{data: make(map[string]PendingDeletion)}
+}
+
+func (s *memPendingDeletionStore) SavePendingDeletion(d PendingDeletion) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data[d.PeerID] = d
+	return nil
+}
+
+func (s *memPendingDeletionStore) DeletePendingDeletion(peerID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.data, peerID)
+	return nil
+}
+
+func (s *memPendingDeletionStore) ListPendingDeletions() ([]PendingDeletion, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]PendingDeletion, 0, len(s.data))
+	for _, d := range s.data {
+		out = append(out, d)
+	}
+	return out, nil
+}
+
+func waitFor(t *testing.T, timeout time.Duration, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		if cond() {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatal("condition not met before timeout")
+}
+
+func TestEphemeralManager_TTLZeroDeletesImmediately(t *testing.T) {
+	store := newMemPendingDeletionStore()
+	var deleted []string
+	var mu sync.Mutex
+
+	m := NewEphemeralManager(store, func(accountID, peerID string) error {
+		mu.Lock()
+		defer mu.Unlock()
+		deleted = append(deleted, peerID)
+		return nil
+	}, nil)
+
+	if err := m.OnPeerStatusChange("acc1", "peer1", false, 0); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	waitFor(t, time.Second, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(deleted) == 1
+	})
+}
+
+func TestEphemeralManager_ReconnectCancelsDeletion(t *testing.T) {
+	store := newMemPendingDeletionStore()
+	var deletedCount int
+	var mu sync.Mutex
+
+	m := NewEphemeralManager(store, func(accountID, peerID string) error {
+		mu.Lock()
+		defer mu.Unlock()
+		deletedCount++
+		return nil
+	}, nil)
+
+	if err := m.OnPeerStatusChange("acc1", "peer1", false, 50*time.Millisecond); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// Reconnect before the grace period elapses.
+	if err := m.OnPeerStatusChange("acc1", "peer1", true, 50*time.Millisecond); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	time.Sleep(150 * time.Millisecond)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if deletedCount != 0 {
+		t.Fatalf("expected reconnect to cancel deletion, got %d deletions", deletedCount)
+	}
+
+	pending, _ := store.ListPendingDeletions()
+	if len(pending) != 0 {
+		t.Fatalf("expected no persisted pending deletions after reconnect, got %d", len(pending))
+	}
+}
+
+// TestEphemeralManager_StaleFireAfterCancel simulates the race where a timer's callback starts running
+// concurrently with a CancelDeletion (e.g. a rapid reconnect racing the timer firing): it exercises fire()
+// directly with the generation that was current before the cancel, as if timer.Stop() had returned false.
+func TestEphemeralManager_StaleFireAfterCancel(t *testing.T) {
+	store := newMemPendingDeletionStore()
+	var deletedCount int
+	var mu sync.Mutex
+
+	m := NewEphemeralManager(store, func(accountID, peerID string) error {
+		mu.Lock()
+		defer mu.Unlock()
+		deletedCount++
+		return nil
+	}, nil)
+
+	if err := m.ScheduleDeletion("acc1", "peer1", time.Hour); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	staleGen := m.generation["peer1"]
+
+	// Peer reconnects: cancels the deletion (as if timer.Stop() had raced and lost).
+	m.CancelDeletion("peer1")
+
+	// The already-in-flight timer callback now runs with the pre-cancel generation.
+	m.fire("acc1", "peer1", staleGen)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if deletedCount != 0 {
+		t.Fatalf("expected stale fire to be a no-op, got %d deletions", deletedCount)
+	}
+	if got := testutil.ToFloat64(m.pendingGauge); got != 0 {
+		t.Fatalf("expected pending gauge to be 0 after cancel+stale fire, got %v", got)
+	}
+}
+
+// TestEphemeralManager_GenerationNeverReused exercises disconnect -> reconnect -> disconnect: each
+// ScheduleDeletion must get a generation that was never used before for this peer, even though the
+// peer's per-peer generation bookkeeping is deleted on cancel. Otherwise a stale fire() left over from the
+// first disconnect could match the second disconnect's freshly armed timer and reap the peer early.
+func TestEphemeralManager_GenerationNeverReused(t *testing.T) {
+	store := newMemPendingDeletionStore()
+	var deletedCount int
+	var mu sync.Mutex
+
+	m := NewEphemeralManager(store, func(accountID, peerID string) error {
+		mu.Lock()
+		defer mu.Unlock()
+		deletedCount++
+		return nil
+	}, nil)
+
+	// First disconnect.
+	if err := m.OnPeerStatusChange("acc1", "peer1", false, time.Hour); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	firstGen := m.generation["peer1"]
+
+	// Reconnect cancels it.
+	if err := m.OnPeerStatusChange("acc1", "peer1", true, time.Hour); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Second disconnect re-arms with a fresh generation.
+	if err := m.OnPeerStatusChange("acc1", "peer1", false, time.Hour); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	secondGen := m.generation["peer1"]
+
+	if firstGen == secondGen {
+		t.Fatalf("expected a fresh generation after re-arming, got reused value %d", firstGen)
+	}
+
+	// A stale fire() left over from the first disconnect must be a no-op against the second schedule.
+	m.fire("acc1", "peer1", firstGen)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if deletedCount != 0 {
+		t.Fatalf("expected stale fire from the first disconnect to be a no-op, got %d deletions", deletedCount)
+	}
+	if got := testutil.ToFloat64(m.pendingGauge); got != 1 {
+		t.Fatalf("expected the second schedule to still be pending, got gauge=%v", got)
+	}
+}
+
+func TestEphemeralManager_LoadAndArm_ReArmsAfterRestart(t *testing.T) {
+	store := newMemPendingDeletionStore()
+	// Simulate a deletion that was scheduled before a restart, already past its deadline.
+	_ = store.SavePendingDeletion(PendingDeletion{PeerID: "peer1", AccountID: "acc1", DeleteAt: time.Now().Add(-time.Minute)})
+
+	var deleted []string
+	var mu sync.Mutex
+
+	m := NewEphemeralManager(store, func(accountID, peerID string) error {
+		mu.Lock()
+		defer mu.Unlock()
+		deleted = append(deleted, peerID)
+		return nil
+	}, nil)
+
+	if err := m.LoadAndArm(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	waitFor(t, time.Second, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(deleted) == 1
+	})
+}
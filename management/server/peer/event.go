@@ -0,0 +1,155 @@
+package peer
+
+import (
+	"net"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// PeerEventType identifies the kind of change a PeerEvent describes.
+type PeerEventType string
+
+const (
+	PeerEventAdded         PeerEventType = "peer.added"
+	PeerEventRemoved       PeerEventType = "peer.removed"
+	PeerEventLogin         PeerEventType = "peer.login"
+	PeerEventLoginExpired  PeerEventType = "peer.login_expired"
+	PeerEventStatusChanged PeerEventType = "peer.status_changed"
+	PeerEventMetaChanged   PeerEventType = "peer.meta_changed"
+	PeerEventSSHEnabled    PeerEventType = "peer.ssh_enabled"
+	PeerEventSSHDisabled   PeerEventType = "peer.ssh_disabled"
+)
+
+// PeerEvent is a typed replacement for the untyped map[string]any previously returned by Peer.EventMeta.
+// It gives operators a stable audit/integration surface (SIEM, IdP deprovisioning, asset inventory)
+// instead of scraping logs.
+type PeerEvent struct {
+	Type      PeerEventType  `json:"type"`
+	PeerID    string         `json:"peer_id"`
+	AccountID string         `json:"account_id"`
+	Name      string         `json:"name,omitempty"`
+	FQDN      string         `json:"fqdn"`
+	IP        net.IP         `json:"ip"`
+	Location  Location       `json:"location"`
+	Meta      PeerSystemMeta `json:"meta"`
+	// OldMeta is set only for PeerEventMetaChanged and holds the metadata prior to the change, so
+	// subscribers can diff old vs. new without keeping their own state.
+	OldMeta *PeerSystemMeta `json:"old_meta,omitempty"`
+	// Expired mirrors PeerStatus.Expired at the time of the event.
+	Expired bool `json:"expired"`
+	// Tags mirrors the peer's tags at the time of the event.
+	Tags      []PeerTag `json:"tags,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+	// Actor identifies who/what triggered the event, e.g. a user ID, "system", or a setup key ID.
+	Actor string `json:"actor"`
+}
+
+// NewPeerEvent builds a PeerEvent of eventType from the current state of p.
+func NewPeerEvent(eventType PeerEventType, p *Peer, dnsDomain, actor string) PeerEvent {
+	var expired bool
+	if p.Status != nil {
+		expired = p.Status.Expired
+	}
+
+	return PeerEvent{
+		Type:      eventType,
+		PeerID:    p.ID,
+		AccountID: p.AccountID,
+		Name:      p.Name,
+		FQDN:      p.FQDN(dnsDomain),
+		IP:        p.IP,
+		Location:  p.Location,
+		Meta:      p.Meta,
+		Expired:   expired,
+		Tags:      p.Tags,
+		Timestamp: time.Now().UTC(),
+		Actor:     actor,
+	}
+}
+
+// ToLegacyMeta renders the event in the shape historically returned by Peer.EventMeta, so sinks that
+// haven't migrated to the typed PeerEvent keep working unchanged. It shares legacyPeerMeta with
+// Peer.EventMeta itself so the two shapes can't drift apart.
+func (e PeerEvent) ToLegacyMeta() map[string]any {
+	return legacyPeerMeta(e.Name, e.FQDN, e.IP, e.Expired, e.Tags)
+}
+
+// legacyPeerMeta builds the activity event meta map in the shape historically returned by
+// Peer.EventMeta. It is the single source of truth for that shape, used by both Peer.EventMeta and
+// PeerEvent.ToLegacyMeta so they cannot diverge.
+func legacyPeerMeta(name, fqdn string, ip net.IP, expired bool, tags []PeerTag) map[string]any {
+	return map[string]any{"name": name, "fqdn": fqdn, "ip": ip, "expired": expired, "tags": tags}
+}
+
+// UpdateMetaIfNewWithEvent behaves like Peer.UpdateMetaIfNew but, when the metadata actually changed,
+// also returns a PeerEventMetaChanged event with OldMeta populated for diffing.
+func (p *Peer) UpdateMetaIfNewWithEvent(meta PeerSystemMeta, dnsDomain, actor string) (bool, *PeerEvent) {
+	old := p.Meta
+	if !p.UpdateMetaIfNew(meta) {
+		return false, nil
+	}
+
+	event := NewPeerEvent(PeerEventMetaChanged, p, dnsDomain, actor)
+	event.OldMeta = &old
+	return true, &event
+}
+
+// PeerEventSink receives published PeerEvents. Implementations must be safe for concurrent use.
+type PeerEventSink interface {
+	Publish(event PeerEvent) error
+}
+
+// EventBus fans a PeerEvent out to every registered sink. A failing sink only logs the error; it never
+// stops delivery to the others. Sinks are called one after another on the publishing goroutine, so a slow
+// sink delays the sinks after it.
+type EventBus struct {
+	mu    sync.RWMutex
+	sinks []PeerEventSink
+}
+
+// NewEventBus creates an EventBus with an initial set of sinks (may be empty).
+func NewEventBus(sinks ...PeerEventSink) *EventBus {
+	return &EventBus{sinks: sinks}
+}
+
+// AddSink registers an additional sink to receive future events.
+func (b *EventBus) AddSink(sink PeerEventSink) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.sinks = append(b.sinks, sink)
+}
+
+// Publish delivers event to every registered sink.
+func (b *EventBus) Publish(event PeerEvent) {
+	b.mu.RLock()
+	sinks := make([]PeerEventSink, len(b.sinks))
+	copy(sinks, b.sinks)
+	b.mu.RUnlock()
+
+	for _, sink := range sinks {
+		if err := sink.Publish(event); err != nil {
+			log.Errorf("peer event bus: sink failed to publish %s event for peer %s: %v", event.Type, event.PeerID, err)
+		}
+	}
+}
+
+// InProcessSink adapts a PeerEvent to the legacy map[string]any shape and hands it to handler, e.g. the
+// existing activity log writer, so it needs no changes to keep working.
+type InProcessSink struct {
+	handler func(accountID string, meta map[string]any)
+}
+
+// NewInProcessSink creates an InProcessSink that calls handler for every published event.
+func NewInProcessSink(handler func(accountID string, meta map[string]any)) *InProcessSink {
+	return &InProcessSink{handler: handler}
+}
+
+// Publish implements PeerEventSink.
+func (s *InProcessSink) Publish(event PeerEvent) error {
+	if s.handler != nil {
+		s.handler(event.AccountID, event.ToLegacyMeta())
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package peer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/nats-io/nats.go"
+	kafka "github.com/segmentio/kafka-go"
+)
+
+// QueueSink publishes PeerEvents as JSON onto an external message queue for downstream consumers such as
+// SIEMs, IdP deprovisioning workflows, or asset inventory systems.
+type QueueSink struct {
+	subject string
+	publish func(ctx context.Context, subject string, payload []byte) error
+}
+
+// NewNATSSink creates a QueueSink that publishes to a NATS subject via conn.
+func NewNATSSink(conn *nats.Conn, subject string) *QueueSink {
+	return &QueueSink{
+		subject: subject,
+		publish: func(_ context.Context, subject string, payload []byte) error {
+			return conn.Publish(subject, payload)
+		},
+	}
+}
+
+// NewKafkaSink creates a QueueSink that publishes to a Kafka topic via writer. topic is set on every
+// message, so exactly one of writer's Topic and topic must be non-empty: kafka-go rejects a message
+// topic when the writer has its own, and a message with neither.
+func NewKafkaSink(writer *kafka.Writer, topic string) *QueueSink {
+	return &QueueSink{
+		subject: topic,
+		publish: func(ctx context.Context, topic string, payload []byte) error {
+			return writer.WriteMessages(ctx, kafka.Message{Topic: topic, Value: payload})
+		},
+	}
+}
+
+// Publish implements PeerEventSink.
+func (s *QueueSink) Publish(event PeerEvent) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("marshal peer event: %w", err)
+	}
+
+	if err := s.publish(context.Background(), s.subject, payload); err != nil {
+		return fmt.Errorf("publish peer event to %s: %w", s.subject, err)
+	}
+	return nil
+}
@@ -0,0 +1,159 @@
+package peer
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+type recordingSink struct {
+	mu     sync.Mutex
+	events []PeerEvent
+	err    error
+}
+
+func (s *recordingSink) Publish(event PeerEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, event)
+	return s.err
+}
+
+func (s *recordingSink) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.events)
+}
+
+func TestEventBus_FanOut(t *testing.T) {
+	sinkA := &recordingSink{}
+	sinkB := &recordingSink{err: fmt.Errorf("boom")}
+
+	bus := NewEventBus(sinkA, sinkB)
+
+	p := &Peer{ID: "peer1", AccountID: "acc1", Name: "peer1"}
+	bus.Publish(NewPeerEvent(PeerEventAdded, p, "netbird.cloud", "system"))
+
+	if sinkA.count() != 1 {
+		t.Fatalf("expected sinkA to receive 1 event, got %d", sinkA.count())
+	}
+	// A failing sink must not prevent delivery to other sinks, nor should it panic.
+	if sinkB.count() != 1 {
+		t.Fatalf("expected sinkB to still receive the event despite returning an error, got %d", sinkB.count())
+	}
+}
+
+func TestInProcessSink_LegacyShape(t *testing.T) {
+	var got map[string]any
+	sink := NewInProcessSink(func(accountID string, meta map[string]any) {
+		got = meta
+	})
+
+	p := &Peer{ID: "peer1", AccountID: "acc1", Name: "peer1", DNSLabel: "peer1", Status: &PeerStatus{Expired: true}}
+	p.AddTag("env", "prod")
+	event := NewPeerEvent(PeerEventLogin, p, "netbird.cloud", "user1")
+
+	if err := sink.Publish(event); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The legacy shape's name, fqdn and expired values must match Peer.EventMeta's own output.
+	want := p.EventMeta("netbird.cloud")
+	if got["name"] != want["name"] || got["fqdn"] != want["fqdn"] || got["expired"] != want["expired"] {
+		t.Fatalf("legacy meta diverged from Peer.EventMeta: got %+v want %+v", got, want)
+	}
+
+	if got["name"] != "peer1" || got["fqdn"] != "peer1.netbird.cloud" {
+		t.Fatalf("unexpected legacy meta: %+v", got)
+	}
+}
+
+func TestPeer_UpdateMetaIfNewWithEvent(t *testing.T) {
+	p := &Peer{ID: "peer1", Meta: PeerSystemMeta{Hostname: "old"}}
+
+	changed, event := p.UpdateMetaIfNewWithEvent(PeerSystemMeta{Hostname: "new"}, "netbird.cloud", "system")
+	if !changed || event == nil {
+		t.Fatal("expected a change and a non-nil event")
+	}
+	if event.Type != PeerEventMetaChanged {
+		t.Fatalf("expected PeerEventMetaChanged, got %s", event.Type)
+	}
+	if event.OldMeta == nil || event.OldMeta.Hostname != "old" {
+		t.Fatalf("expected OldMeta.Hostname=old, got %+v", event.OldMeta)
+	}
+
+	// No-op update should not produce an event.
+	changed, event = p.UpdateMetaIfNewWithEvent(PeerSystemMeta{Hostname: "new"}, "netbird.cloud", "system")
+	if changed || event != nil {
+		t.Fatal("expected no change and a nil event for an identical update")
+	}
+}
+
+func TestWebhookSink_SignsAndDelivers(t *testing.T) {
+	secret := []byte("s3cr3t")
+	var received []byte
+	var sig string
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received = body
+		sig = r.Header.Get(signatureHeader)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	sink := NewWebhookSink(srv.URL, secret, nil)
+
+	p := &Peer{ID: "peer1", AccountID: "acc1", Name: "peer1"}
+	event := NewPeerEvent(PeerEventAdded, p, "netbird.cloud", "system")
+
+	if err := sink.Publish(event); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var decoded PeerEvent
+	if err := json.Unmarshal(received, &decoded); err != nil {
+		t.Fatalf("failed to decode delivered body: %v", err)
+	}
+	if decoded.PeerID != "peer1" {
+		t.Fatalf("unexpected delivered event: %+v", decoded)
+	}
+
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(received)
+	want := hex.EncodeToString(mac.Sum(nil))
+	if sig != want {
+		t.Fatalf("signature mismatch: got %s want %s", sig, want)
+	}
+}
+
+func TestWebhookSink_RetriesOnFailure(t *testing.T) {
+	var attempts int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts < 3 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	sink := NewWebhookSink(srv.URL, []byte("secret"), nil)
+	sink.baseDelay = 0
+
+	p := &Peer{ID: "peer1", AccountID: "acc1"}
+	if err := sink.Publish(NewPeerEvent(PeerEventAdded, p, "netbird.cloud", "system")); err != nil {
+		t.Fatalf("expected eventual success after retries, got: %v", err)
+	}
+	if attempts != 3 {
+		t.Fatalf("expected 3 attempts, got %d", attempts)
+	}
+}
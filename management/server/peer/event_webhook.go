@@ -0,0 +1,96 @@
+package peer
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// signatureHeader carries the hex-encoded HMAC-SHA256 signature of the request body, so receivers can
+// verify the event actually came from this management server.
+const signatureHeader = "X-Netbird-Signature"
+
+// WebhookSink POSTs each PeerEvent as JSON to a configured URL, signing the body with a shared secret and
+// retrying with exponential backoff on failure.
+type WebhookSink struct {
+	url        string
+	secret     []byte
+	client     *http.Client
+	maxRetries int
+	baseDelay  time.Duration
+}
+
+// NewWebhookSink creates a WebhookSink posting to url, signed with secret. client may be nil to use a
+// default http.Client with a 10s timeout.
+func NewWebhookSink(url string, secret []byte, client *http.Client) *WebhookSink {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &WebhookSink{
+		url:        url,
+		secret:     secret,
+		client:     client,
+		maxRetries: 3,
+		baseDelay:  500 * time.Millisecond,
+	}
+}
+
+// Publish implements PeerEventSink.
+func (s *WebhookSink) Publish(event PeerEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("marshal peer event: %w", err)
+	}
+
+	sig := s.sign(body)
+
+	var lastErr error
+	delay := s.baseDelay
+	for attempt := 0; attempt <= s.maxRetries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(delay)
+			delay *= 2
+		}
+
+		if lastErr = s.send(body, sig); lastErr == nil {
+			return nil
+		}
+
+		log.Warnf("peer event webhook: attempt %d/%d failed: %v", attempt+1, s.maxRetries+1, lastErr)
+	}
+
+	return fmt.Errorf("deliver peer event webhook after %d attempts: %w", s.maxRetries+1, lastErr)
+}
+
+func (s *WebhookSink) send(body, sig []byte) error {
+	req, err := http.NewRequest(http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("build webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(signatureHeader, hex.EncodeToString(sig))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("send webhook request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook endpoint returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func (s *WebhookSink) sign(body []byte) []byte {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write(body)
+	return mac.Sum(nil)
+}
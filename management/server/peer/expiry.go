@@ -0,0 +1,105 @@
+package peer
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// minClockDeltaToApply is the minimum absolute clock skew worth compensating for. Smaller deltas are
+// within normal NTP drift and are ignored so we don't jitter expiry decisions on noise.
+const minClockDeltaToApply = time.Minute
+
+// minControlTime is a hard floor for any reported control time. It guards against a misbehaving or
+// misconfigured client/heartbeat reporting a bogus (e.g. zero-value or pre-release) time that would
+// otherwise produce a huge, wrong clockDelta.
+var minControlTime = time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// ExpiryManager tracks per-account clock skew between this server and the authoritative control time
+// (e.g. derived from a client's heartbeat), and keeps track of which peers have already been reported as
+// login-expired so that callers can emit a state-transition notification exactly once.
+type ExpiryManager struct {
+	mu sync.Mutex
+
+	// clockDeltas holds, per account, the duration to add to time.Now() to approximate control time.
+	clockDeltas map[string]time.Duration
+
+	// expired holds the set of peer IDs that are currently known to be in the expired state.
+	expired map[string]struct{}
+}
+
+// NewExpiryManager creates a new ExpiryManager.
+func NewExpiryManager() *ExpiryManager {
+	return &ExpiryManager{
+		clockDeltas: make(map[string]time.Duration),
+		expired:     make(map[string]struct{}),
+	}
+}
+
+// SetControlTime records the clock skew for accountID given an authoritative control time
+// (e.g. taken from a client's heartbeat). Returns an error if controlTime predates minControlTime.
+func (m *ExpiryManager) SetControlTime(accountID string, controlTime time.Time) error {
+	if controlTime.Before(minControlTime) {
+		return fmt.Errorf("control time %s is before the minimum allowed %s", controlTime, minControlTime)
+	}
+
+	delta := controlTime.Sub(time.Now())
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.clockDeltas[accountID] = delta
+	return nil
+}
+
+// ClockDelta returns the currently known clock skew for accountID, or 0 if none has been recorded.
+func (m *ExpiryManager) ClockDelta(accountID string) time.Duration {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.clockDeltas[accountID]
+}
+
+// NextExpiry returns the earliest login expiry among peers, in this server's local time, so a single
+// timer can be armed to fire slightly after it. The result is in the past if an eligible peer has already
+// expired. It returns the zero Time if no peer is eligible.
+func (m *ExpiryManager) NextExpiry(peers []*Peer, expiresIn time.Duration) time.Time {
+	var next time.Time
+	for _, p := range peers {
+		if !p.AddedWithSSOLogin() || !p.LoginExpirationEnabled {
+			continue
+		}
+
+		delta := m.ClockDelta(p.AccountID)
+		if delta.Abs() < minClockDeltaToApply {
+			delta = 0
+		}
+
+		// p.LoginExpiredAt compares LastLogin+expiresIn against time.Now().Add(delta); the local wall-clock
+		// moment at which that comparison flips is expiresAt-delta.
+		armAt := p.LastLogin.Add(expiresIn).Add(-delta)
+		if next.IsZero() || armAt.Before(next) {
+			next = armAt
+		}
+	}
+	return next
+}
+
+// MarkExpired records peerID as expired and reports whether this is a new transition (i.e. the peer
+// wasn't already known to be expired). Callers should only emit an expiry notification when true is returned.
+func (m *ExpiryManager) MarkExpired(peerID string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.expired[peerID]; ok {
+		return false
+	}
+	m.expired[peerID] = struct{}{}
+	return true
+}
+
+// MarkNotExpired clears peerID's expired state, e.g. after the peer logs in again. It should be called
+// whenever UpdateLastLogin resets a peer's login expiry.
+func (m *ExpiryManager) MarkNotExpired(peerID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.expired, peerID)
+}
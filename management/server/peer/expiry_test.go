@@ -0,0 +1,157 @@
+package peer
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLoginExpired_NoClockDelta(t *testing.T) {
+	p := &Peer{
+		UserID:                 "user1",
+		LoginExpirationEnabled: true,
+		LastLogin:              time.Now().Add(-2 * time.Hour),
+	}
+
+	expired, _ := p.LoginExpired(time.Hour)
+	if !expired {
+		t.Fatal("expected login to be expired")
+	}
+}
+
+func TestLoginExpiredAt_ClockSkew(t *testing.T) {
+	now := time.Now()
+
+	testCases := []struct {
+		name       string
+		lastLogin  time.Time
+		expiresIn  time.Duration
+		clockDelta time.Duration
+		expired    bool
+	}{
+		{
+			name:       "no skew, not expired",
+			lastLogin:  now.Add(-30 * time.Minute),
+			expiresIn:  time.Hour,
+			clockDelta: 0,
+			expired:    false,
+		},
+		{
+			name:       "no skew, expired",
+			lastLogin:  now.Add(-2 * time.Hour),
+			expiresIn:  time.Hour,
+			clockDelta: 0,
+			expired:    true,
+		},
+		{
+			name:       "server clock behind control time beyond threshold pushes peer into expiry",
+			lastLogin:  now.Add(-55 * time.Minute),
+			expiresIn:  time.Hour,
+			clockDelta: 10 * time.Minute,
+			expired:    true,
+		},
+		{
+			name:       "small skew under threshold is ignored",
+			lastLogin:  now.Add(-55 * time.Minute),
+			expiresIn:  time.Hour,
+			clockDelta: 30 * time.Second,
+			expired:    false,
+		},
+		{
+			name:       "server clock ahead of control time keeps peer valid longer",
+			lastLogin:  now.Add(-65 * time.Minute),
+			expiresIn:  time.Hour,
+			clockDelta: -10 * time.Minute,
+			expired:    false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := &Peer{
+				UserID:                 "user1",
+				LoginExpirationEnabled: true,
+				LastLogin:              tc.lastLogin,
+			}
+
+			expired, _ := p.LoginExpiredAt(tc.expiresIn, tc.clockDelta)
+			if expired != tc.expired {
+				t.Fatalf("expected expired=%v, got %v", tc.expired, expired)
+			}
+		})
+	}
+}
+
+func TestExpiryManager_SetControlTime(t *testing.T) {
+	m := NewExpiryManager()
+
+	if err := m.SetControlTime("account1", minControlTime.Add(-time.Second)); err == nil {
+		t.Fatal("expected error for control time before minControlTime")
+	}
+
+	future := time.Now().Add(15 * time.Minute)
+	if err := m.SetControlTime("account1", future); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	delta := m.ClockDelta("account1")
+	if delta < 14*time.Minute || delta > 16*time.Minute {
+		t.Fatalf("expected delta around 15 minutes, got %s", delta)
+	}
+
+	if d := m.ClockDelta("unknown-account"); d != 0 {
+		t.Fatalf("expected zero delta for unknown account, got %s", d)
+	}
+}
+
+func TestExpiryManager_NextExpiry(t *testing.T) {
+	m := NewExpiryManager()
+	now := time.Now()
+
+	peers := []*Peer{
+		{
+			ID:                     "peer1",
+			AccountID:              "account1",
+			UserID:                 "user1",
+			LoginExpirationEnabled: true,
+			LastLogin:              now.Add(-30 * time.Minute),
+		},
+		{
+			ID:                     "peer2",
+			AccountID:              "account1",
+			UserID:                 "user1",
+			LoginExpirationEnabled: true,
+			LastLogin:              now.Add(-50 * time.Minute),
+		},
+		{
+			// not SSO-added, must be ignored
+			ID:                     "peer3",
+			AccountID:              "account1",
+			LoginExpirationEnabled: true,
+			LastLogin:              now.Add(-59 * time.Minute),
+		},
+	}
+
+	expiresIn := time.Hour
+	next := m.NextExpiry(peers, expiresIn)
+
+	want := peers[1].LastLogin.Add(expiresIn)
+	if next.Sub(want).Abs() > time.Second {
+		t.Fatalf("expected soonest expiry around %s, got %s", want, next)
+	}
+}
+
+func TestExpiryManager_MarkExpired_SingleShot(t *testing.T) {
+	m := NewExpiryManager()
+
+	if transitioned := m.MarkExpired("peer1"); !transitioned {
+		t.Fatal("expected first MarkExpired call to report a transition")
+	}
+	if transitioned := m.MarkExpired("peer1"); transitioned {
+		t.Fatal("expected repeated MarkExpired call to not report a transition")
+	}
+
+	m.MarkNotExpired("peer1")
+	if transitioned := m.MarkExpired("peer1"); !transitioned {
+		t.Fatal("expected MarkExpired to report a transition again after MarkNotExpired")
+	}
+}
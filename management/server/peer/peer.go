@@ -43,6 +43,9 @@ type Peer struct {
 	Ephemeral bool
 	// Geo location based on connection IP
 	Location Location `gorm:"embedded;embeddedPrefix:location_"`
+	// Tags are Key/Value labels used to select peers for ACL rules, routing groups, and DNS nameserver
+	// groups. They can be inherited from a setup key, set via the management API, or synced from SSO claims.
+	Tags []PeerTag `gorm:"foreignKey:PeerID;references:ID"`
 }
 
 type PeerStatus struct {
@@ -54,6 +57,11 @@ type PeerStatus struct {
 	LoginExpired bool
 	// RequiresApproval indicates whether peer requires approval or not
 	RequiresApproval bool
+	// Expired indicates that the peer's key/registration is no longer valid from the control plane's
+	// perspective, either because the account explicitly marked it so or because an optimistic
+	// client-side expiry deadline has passed. Unlike LoginExpired, which only reflects SSO re-auth
+	// state, Expired governs whether the peer should still be usable as a routing/exit peer at all.
+	Expired bool
 }
 
 // Location is a geo location information of a Peer based on public connection IP
@@ -62,6 +70,39 @@ type Location struct {
 	CountryCode  string
 	CityName     string
 	GeoNameID    uint // city level geoname id
+	// Continent is the continent code (e.g. "EU", "NA") the connection IP resolves to
+	Continent string
+	// ASN is the autonomous system number the connection IP belongs to
+	ASN uint
+	// ASOrg is the organization name registered for ASN
+	ASOrg string
+}
+
+// GeoResolver resolves a geo location (including ASN data) for a given IP address. Implementations are
+// expected to be safe for concurrent use, as peers across accounts may be resolved concurrently.
+type GeoResolver interface {
+	Resolve(ip net.IP) (Location, error)
+}
+
+// UpdateLocation resolves connectionIP via resolver and updates the peer's Location accordingly.
+// It is a no-op, returning false, if connectionIP is unchanged from the peer's current Location or if
+// resolver is nil (e.g. geo location resolution is disabled). Returns true if the Location was updated.
+func (p *Peer) UpdateLocation(resolver GeoResolver, connectionIP net.IP) (bool, error) {
+	if resolver == nil || connectionIP == nil {
+		return false, nil
+	}
+	if p.Location.ConnectionIP.Equal(connectionIP) {
+		return false, nil
+	}
+
+	loc, err := resolver.Resolve(connectionIP)
+	if err != nil {
+		return false, fmt.Errorf("resolve location for ip %s: %w", connectionIP, err)
+	}
+
+	loc.ConnectionIP = connectionIP
+	p.Location = loc
+	return true, nil
 }
 
 // PeerSystemMeta is a metadata of a Peer machine system
@@ -102,6 +143,8 @@ func (p *Peer) Copy() *Peer {
 	if peerStatus != nil {
 		peerStatus = p.Status.Copy()
 	}
+	tags := make([]PeerTag, len(p.Tags))
+	copy(tags, p.Tags)
 	return &Peer{
 		ID:                     p.ID,
 		AccountID:              p.AccountID,
@@ -119,6 +162,7 @@ func (p *Peer) Copy() *Peer {
 		LastLogin:              p.LastLogin,
 		Ephemeral:              p.Ephemeral,
 		Location:               p.Location,
+		Tags:                   tags,
 	}
 }
 
@@ -141,6 +185,7 @@ func (p *Peer) UpdateMetaIfNew(meta PeerSystemMeta) bool {
 func (p *Peer) MarkLoginExpired(expired bool) {
 	newStatus := p.Status.Copy()
 	newStatus.LoginExpired = expired
+	newStatus.Expired = expired
 	if expired {
 		newStatus.Connected = false
 	}
@@ -153,12 +198,26 @@ func (p *Peer) MarkLoginExpired(expired bool) {
 // Login expiration can be disabled/enabled on a Peer level via Peer.LoginExpirationEnabled property.
 // Login expiration can also be disabled/enabled globally on the Account level via Settings.PeerLoginExpirationEnabled.
 // Only peers added by interactive SSO login can be expired.
+// This is a shorthand for LoginExpiredAt with a zero clockDelta, i.e. it assumes the local clock matches
+// the authoritative control time. Callers that have a clockDelta from ExpiryManager should call
+// LoginExpiredAt directly instead.
 func (p *Peer) LoginExpired(expiresIn time.Duration) (bool, time.Duration) {
+	return p.LoginExpiredAt(expiresIn, 0)
+}
+
+// LoginExpiredAt behaves like LoginExpired but additionally compensates for clock skew between the
+// management server and whatever produced LastLogin. clockDelta is the offset to apply to the local
+// clock to approximate the authoritative control time (control time - local time); it is obtained from
+// ExpiryManager. Deltas smaller than minClockDeltaToApply are ignored.
+func (p *Peer) LoginExpiredAt(expiresIn time.Duration, clockDelta time.Duration) (bool, time.Duration) {
 	if !p.AddedWithSSOLogin() || !p.LoginExpirationEnabled {
 		return false, 0
 	}
+	if clockDelta.Abs() < minClockDeltaToApply {
+		clockDelta = 0
+	}
 	expiresAt := p.LastLogin.Add(expiresIn)
-	now := time.Now()
+	now := time.Now().Add(clockDelta)
 	timeLeft := expiresAt.Sub(now)
 	return timeLeft <= 0, timeLeft
 }
@@ -171,9 +230,14 @@ func (p *Peer) FQDN(dnsDomain string) string {
 	return fmt.Sprintf("%s.%s", p.DNSLabel, dnsDomain)
 }
 
-// EventMeta returns activity event meta related to the peer
+// EventMeta returns activity event meta related to the peer. Its shape is shared with
+// PeerEvent.ToLegacyMeta via legacyPeerMeta so the two cannot drift apart.
 func (p *Peer) EventMeta(dnsDomain string) map[string]any {
-	return map[string]any{"name": p.Name, "fqdn": p.FQDN(dnsDomain), "ip": p.IP}
+	var expired bool
+	if p.Status != nil {
+		expired = p.Status.Expired
+	}
+	return legacyPeerMeta(p.Name, p.FQDN(dnsDomain), p.IP, expired, p.Tags)
 }
 
 // Copy PeerStatus
@@ -183,6 +247,7 @@ func (p *PeerStatus) Copy() *PeerStatus {
 		Connected:        p.Connected,
 		LoginExpired:     p.LoginExpired,
 		RequiresApproval: p.RequiresApproval,
+		Expired:          p.Expired,
 	}
 }
 
@@ -191,6 +256,7 @@ func (p *Peer) UpdateLastLogin() *Peer {
 	p.LastLogin = time.Now().UTC()
 	newStatus := p.Status.Copy()
 	newStatus.LoginExpired = false
+	newStatus.Expired = false
 	p.Status = newStatus
 	return p
 }
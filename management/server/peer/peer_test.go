@@ -0,0 +1,97 @@
+package peer
+
+import (
+	"net"
+	"testing"
+)
+
+type mockGeoResolver struct {
+	loc Location
+	err error
+}
+
+func (m *mockGeoResolver) Resolve(ip net.IP) (Location, error) {
+	return m.loc, m.err
+}
+
+func TestPeer_MarkLoginExpired_SetsExpired(t *testing.T) {
+	p := &Peer{Status: &PeerStatus{Connected: true}}
+
+	p.MarkLoginExpired(true)
+	if !p.Status.LoginExpired || !p.Status.Expired {
+		t.Fatalf("expected LoginExpired and Expired to be true, got %+v", p.Status)
+	}
+	if p.Status.Connected {
+		t.Fatal("expected peer to be disconnected once login expired")
+	}
+
+	p.MarkLoginExpired(false)
+	if p.Status.LoginExpired || p.Status.Expired {
+		t.Fatalf("expected LoginExpired and Expired to be false, got %+v", p.Status)
+	}
+}
+
+func TestPeer_UpdateLastLogin_ClearsExpired(t *testing.T) {
+	p := &Peer{Status: &PeerStatus{LoginExpired: true, Expired: true}}
+
+	p.UpdateLastLogin()
+
+	if p.Status.LoginExpired || p.Status.Expired {
+		t.Fatalf("expected LoginExpired and Expired to be cleared, got %+v", p.Status)
+	}
+	if p.LastLogin.IsZero() {
+		t.Fatal("expected LastLogin to be set")
+	}
+}
+
+func TestPeer_EventMeta_IncludesExpired(t *testing.T) {
+	p := &Peer{Name: "peer1", Status: &PeerStatus{Expired: true}}
+
+	meta := p.EventMeta("netbird.cloud")
+	if expired, _ := meta["expired"].(bool); !expired {
+		t.Fatalf("expected expired=true in event meta, got %v", meta["expired"])
+	}
+}
+
+func TestPeer_EventMeta_NilStatus(t *testing.T) {
+	p := &Peer{Name: "peer1"}
+
+	meta := p.EventMeta("netbird.cloud")
+	if expired, _ := meta["expired"].(bool); expired {
+		t.Fatalf("expected expired=false for nil status, got %v", meta["expired"])
+	}
+}
+
+func TestPeer_UpdateLocation(t *testing.T) {
+	resolver := &mockGeoResolver{loc: Location{CountryCode: "DE", CityName: "Berlin", ASN: 64500}}
+	ip := net.ParseIP("203.0.113.10")
+
+	p := &Peer{}
+	updated, err := p.UpdateLocation(resolver, ip)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !updated {
+		t.Fatal("expected location to be updated on first resolve")
+	}
+	if p.Location.CountryCode != "DE" || !p.Location.ConnectionIP.Equal(ip) {
+		t.Fatalf("unexpected location: %+v", p.Location)
+	}
+
+	// Resolving the same connection IP again should be a no-op.
+	updated, err = p.UpdateLocation(resolver, ip)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if updated {
+		t.Fatal("expected no update when connection ip is unchanged")
+	}
+}
+
+func TestPeer_UpdateLocation_NilResolver(t *testing.T) {
+	p := &Peer{}
+	updated, err := p.UpdateLocation(nil, net.ParseIP("203.0.113.10"))
+	if err != nil || updated {
+		t.Fatalf("expected no-op for nil resolver, got updated=%v err=%v", updated, err)
+	}
+}
@@ -0,0 +1,93 @@
+package peer
+
+// PeerTag is a single key/value label attached to a Peer, e.g. {Key: "env", Value: "prod"}. Tags are
+// stored in a joined table so a peer can carry any number of them.
+type PeerTag struct {
+	ID     uint   `gorm:"primaryKey;autoIncrement"`
+	PeerID string `gorm:"index"`
+	Key    string `gorm:"index:idx_peer_tags_key_value"`
+	Value  string `gorm:"index:idx_peer_tags_key_value"`
+}
+
+// AddTag adds a Key/Value tag to the peer if it isn't already present.
+func (p *Peer) AddTag(key, value string) {
+	if p.HasTag(key, value) {
+		return
+	}
+	p.Tags = append(p.Tags, PeerTag{PeerID: p.ID, Key: key, Value: value})
+}
+
+// RemoveTag removes a Key/Value tag from the peer, if present.
+func (p *Peer) RemoveTag(key, value string) {
+	for i, t := range p.Tags {
+		if t.Key == key && t.Value == value {
+			p.Tags = append(p.Tags[:i], p.Tags[i+1:]...)
+			return
+		}
+	}
+}
+
+// HasTag reports whether the peer carries the given Key/Value tag.
+func (p *Peer) HasTag(key, value string) bool {
+	for _, t := range p.Tags {
+		if t.Key == key && t.Value == value {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchTags reports whether the peer carries every Key/Value pair in selector, e.g.
+// selector map[string]string{"env": "prod", "role": "db"} matches only peers tagged with both.
+// An empty selector matches every peer.
+func (p *Peer) MatchTags(selector map[string]string) bool {
+	for key, value := range selector {
+		if !p.HasTag(key, value) {
+			return false
+		}
+	}
+	return true
+}
+
+// tagsEqual reports whether p's tags are the same set as other, ignoring order.
+func (p *Peer) tagsEqual(other []PeerTag) bool {
+	if len(p.Tags) != len(other) {
+		return false
+	}
+	for _, t := range other {
+		if !p.HasTag(t.Key, t.Value) {
+			return false
+		}
+	}
+	return true
+}
+
+// UpdateTagsIfNew replaces the peer's tags with newTags if they differ from the current set, returning
+// true if an update was applied. This is the tags counterpart to Peer.UpdateMetaIfNew, used when
+// re-applying tags inherited from a setup key or synced from SSO group claims, so callers can skip a
+// no-op write when nothing actually changed.
+func (p *Peer) UpdateTagsIfNew(newTags []PeerTag) bool {
+	if p.tagsEqual(newTags) {
+		return false
+	}
+	p.Tags = newTags
+	return true
+}
+
+// MatchPeersByTagSelector filters peers to those matching every Key/Value pair in selector.
+// It is the in-memory counterpart of an account store's ListPeersByTagSelector query, letting ACL
+// rules, routing groups, and DNS nameserver groups be expressed against selectors like
+// map[string]string{"env": "prod"} rather than static peer ID lists.
+func MatchPeersByTagSelector(peers []*Peer, selector map[string]string) []*Peer {
+	if len(selector) == 0 {
+		return peers
+	}
+
+	matched := make([]*Peer, 0, len(peers))
+	for _, p := range peers {
+		if p.MatchTags(selector) {
+			matched = append(matched, p)
+		}
+	}
+	return matched
+}
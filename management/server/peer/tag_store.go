@@ -0,0 +1,37 @@
+package peer
+
+import "sync"
+
+// TagSelectorStore is the slice of an account store's peer-query surface needed to express ACL rules,
+// routing groups, and DNS nameserver groups against a tag selector instead of static peer ID lists. A
+// DB-backed account store implements this by querying the peers/peer_tags join tables directly.
+type TagSelectorStore interface {
+	// ListPeersByTagSelector returns every peer of accountID matching every Key/Value pair in selector.
+	ListPeersByTagSelector(accountID string, selector map[string]string) ([]*Peer, error)
+}
+
+// InMemoryPeerStore is a TagSelectorStore backed by an in-memory peer list per account. It's suitable for
+// tests and for any account store that already keeps its peers fully loaded in memory.
+type InMemoryPeerStore struct {
+	mu    sync.RWMutex
+	peers map[string][]*Peer // accountID -> peers
+}
+
+// NewInMemoryPeerStore creates an empty InMemoryPeerStore.
+func NewInMemoryPeerStore() *InMemoryPeerStore {
+	return &InMemoryPeerStore{peers: make(map[string][]*Peer)}
+}
+
+// AddPeer registers p under accountID.
+func (s *InMemoryPeerStore) AddPeer(accountID string, p *Peer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.peers[accountID] = append(s.peers[accountID], p)
+}
+
+// ListPeersByTagSelector implements TagSelectorStore.
+func (s *InMemoryPeerStore) ListPeersByTagSelector(accountID string, selector map[string]string) ([]*Peer, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return MatchPeersByTagSelector(s.peers[accountID], selector), nil
+}
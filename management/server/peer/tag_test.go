@@ -0,0 +1,114 @@
+package peer
+
+import "testing"
+
+func TestPeer_AddRemoveHasTag(t *testing.T) {
+	p := &Peer{ID: "peer1"}
+
+	if p.HasTag("env", "prod") {
+		t.Fatal("expected peer to have no tags initially")
+	}
+
+	p.AddTag("env", "prod")
+	p.AddTag("env", "prod") // duplicate add should be a no-op
+	if len(p.Tags) != 1 {
+		t.Fatalf("expected exactly one tag, got %d", len(p.Tags))
+	}
+	if !p.HasTag("env", "prod") {
+		t.Fatal("expected peer to have env=prod tag")
+	}
+
+	p.AddTag("role", "db")
+	if len(p.Tags) != 2 {
+		t.Fatalf("expected two tags, got %d", len(p.Tags))
+	}
+
+	p.RemoveTag("env", "prod")
+	if p.HasTag("env", "prod") {
+		t.Fatal("expected env=prod tag to be removed")
+	}
+	if len(p.Tags) != 1 {
+		t.Fatalf("expected one tag remaining, got %d", len(p.Tags))
+	}
+}
+
+func TestPeer_MatchTags(t *testing.T) {
+	p := &Peer{ID: "peer1"}
+	p.AddTag("env", "prod")
+	p.AddTag("role", "db")
+
+	if !p.MatchTags(map[string]string{"env": "prod"}) {
+		t.Fatal("expected selector env=prod to match")
+	}
+	if !p.MatchTags(map[string]string{"env": "prod", "role": "db"}) {
+		t.Fatal("expected selector env=prod,role=db to match")
+	}
+	if p.MatchTags(map[string]string{"env": "staging"}) {
+		t.Fatal("expected selector env=staging to not match")
+	}
+	if !p.MatchTags(nil) {
+		t.Fatal("expected empty selector to match any peer")
+	}
+}
+
+func TestMatchPeersByTagSelector(t *testing.T) {
+	prod := &Peer{ID: "peer1"}
+	prod.AddTag("env", "prod")
+
+	staging := &Peer{ID: "peer2"}
+	staging.AddTag("env", "staging")
+
+	peers := []*Peer{prod, staging}
+
+	matched := MatchPeersByTagSelector(peers, map[string]string{"env": "prod"})
+	if len(matched) != 1 || matched[0].ID != "peer1" {
+		t.Fatalf("expected only peer1 to match, got %+v", matched)
+	}
+
+	all := MatchPeersByTagSelector(peers, nil)
+	if len(all) != len(peers) {
+		t.Fatalf("expected empty selector to return all peers, got %d", len(all))
+	}
+}
+
+func TestPeer_UpdateTagsIfNew(t *testing.T) {
+	p := &Peer{ID: "peer1"}
+	p.AddTag("env", "prod")
+
+	// Re-applying the same set (e.g. re-syncing from a setup key) should be a no-op.
+	if p.UpdateTagsIfNew([]PeerTag{{PeerID: "peer1", Key: "env", Value: "prod"}}) {
+		t.Fatal("expected no-op update for an identical tag set")
+	}
+
+	// A genuinely different set (e.g. new SSO group claims) should be applied.
+	newTags := []PeerTag{{PeerID: "peer1", Key: "env", Value: "staging"}, {PeerID: "peer1", Key: "role", Value: "db"}}
+	if !p.UpdateTagsIfNew(newTags) {
+		t.Fatal("expected update to be applied for a different tag set")
+	}
+	if !p.HasTag("env", "staging") || !p.HasTag("role", "db") || p.HasTag("env", "prod") {
+		t.Fatalf("unexpected tags after update: %+v", p.Tags)
+	}
+}
+
+func TestInMemoryPeerStore_ListPeersByTagSelector(t *testing.T) {
+	store := NewInMemoryPeerStore()
+
+	prod := &Peer{ID: "peer1", AccountID: "acc1"}
+	prod.AddTag("env", "prod")
+	staging := &Peer{ID: "peer2", AccountID: "acc1"}
+	staging.AddTag("env", "staging")
+	otherAccount := &Peer{ID: "peer3", AccountID: "acc2"}
+	otherAccount.AddTag("env", "prod")
+
+	store.AddPeer("acc1", prod)
+	store.AddPeer("acc1", staging)
+	store.AddPeer("acc2", otherAccount)
+
+	matched, err := store.ListPeersByTagSelector("acc1", map[string]string{"env": "prod"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(matched) != 1 || matched[0].ID != "peer1" {
+		t.Fatalf("expected only peer1 in acc1 to match, got %+v", matched)
+	}
+}